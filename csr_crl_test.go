@@ -0,0 +1,57 @@
+package obbsubjectextractor
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"testing"
+
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func TestExtractSubjectFromCSR(t *testing.T) {
+	cn := asn1.ObjectIdentifier{2, 5, 4, 3}
+	raw := buildName(t, [][]ava{
+		{{oid: cn, tag: cryptobyte_asn1.PrintableString, value: "api.example.com"}},
+	})
+	csr := &x509.CertificateRequest{RawSubject: raw}
+
+	got, err := ExtractSubjectFromCSR(csr)
+	if err != nil {
+		t.Fatalf("ExtractSubjectFromCSR: %v", err)
+	}
+	if want := "CN=api.example.com"; got != want {
+		t.Errorf("ExtractSubjectFromCSR = %q, want %q", got, want)
+	}
+}
+
+func TestExtractIssuerFromCRL(t *testing.T) {
+	o := asn1.ObjectIdentifier{2, 5, 4, 10}
+	raw := buildName(t, [][]ava{
+		{{oid: o, tag: cryptobyte_asn1.PrintableString, value: "Example CA"}},
+	})
+	crl := &x509.RevocationList{RawIssuer: raw}
+
+	got, err := ExtractIssuerFromCRL(crl)
+	if err != nil {
+		t.Fatalf("ExtractIssuerFromCRL: %v", err)
+	}
+	if want := "O=Example CA"; got != want {
+		t.Errorf("ExtractIssuerFromCRL = %q, want %q", got, want)
+	}
+}
+
+func TestExtractIssuer(t *testing.T) {
+	o := asn1.ObjectIdentifier{2, 5, 4, 10}
+	raw := buildName(t, [][]ava{
+		{{oid: o, tag: cryptobyte_asn1.PrintableString, value: "Example CA"}},
+	})
+	cert := &x509.Certificate{RawIssuer: raw}
+
+	got, err := ExtractIssuer(cert)
+	if err != nil {
+		t.Fatalf("ExtractIssuer: %v", err)
+	}
+	if want := "O=Example CA"; got != want {
+		t.Errorf("ExtractIssuer = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+package obbsubjectextractor
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+const oidSubjectAltName = "2.5.29.17"
+
+// OIDs of the ICP-Brasil OtherName values embedded in the SubjectAltName
+// extension of PF and PJ certificates, per DOC-ICP-04/05.
+const (
+	oidICPBrasilTitularPF          = "2.16.76.1.3.1"
+	oidICPBrasilResponsavelPJNome  = "2.16.76.1.3.2"
+	oidICPBrasilCNPJ               = "2.16.76.1.3.3"
+	oidICPBrasilResponsavelPJDados = "2.16.76.1.3.4"
+	oidICPBrasilTituloEleitor      = "2.16.76.1.3.5"
+	oidICPBrasilCEIPJ              = "2.16.76.1.3.6"
+	oidICPBrasilCEIPF              = "2.16.76.1.3.7"
+)
+
+var otherNameTag = cryptobyte_asn1.Tag(0).ContextSpecific().Constructed()
+
+// isAllDigits reports whether s consists only of ASCII digits, used to
+// validate the numeric ICP-Brasil fields (CPF, PIS, CNPJ, título de
+// eleitor, CEI) beyond their fixed length.
+func isAllDigits(s string) bool {
+	for _, b := range []byte(s) {
+		if b < '0' || b > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ICPBrasilFieldError reports an ICP-Brasil OtherName value that could not
+// be decoded because it did not match the fixed-width layout its OID
+// defines.
+type ICPBrasilFieldError struct {
+	OID    string
+	Reason string
+}
+
+func (e *ICPBrasilFieldError) Error() string {
+	return fmt.Sprintf("obbsubjectextractor: malformed ICP-Brasil otherName %s: %s", e.OID, e.Reason)
+}
+
+// ICPBrasilIdentifiers holds the identifiers ICP-Brasil issuers embed in the
+// SubjectAlternativeName extension (OID 2.5.29.17) of PF and PJ
+// certificates, as used by Open Banking Brasil participants.
+type ICPBrasilIdentifiers struct {
+	// Titular PF fields, decoded from OtherName 2.16.76.1.3.1.
+	DateOfBirth time.Time
+	CPF         string
+	PIS         string
+	RG          string
+	RGEmissor   string
+
+	// PJ fields.
+	ResponsavelNome string // 2.16.76.1.3.2
+	CNPJ            string // 2.16.76.1.3.3
+
+	// Responsável PJ fields, decoded from OtherName 2.16.76.1.3.4, which
+	// shares the layout of 2.16.76.1.3.1.
+	ResponsavelDateOfBirth time.Time
+	ResponsavelCPF         string
+	ResponsavelPIS         string
+	ResponsavelRG          string
+	ResponsavelRGEmissor   string
+
+	VoterID string // título de eleitor, 2.16.76.1.3.5
+	CEIPJ   string // 2.16.76.1.3.6
+	CEIPF   string // 2.16.76.1.3.7
+}
+
+// splitTitularPF decodes the fixed-width layout shared by OtherNames
+// 2.16.76.1.3.1 and 2.16.76.1.3.4: an 8-digit ddmmyyyy date of birth, an
+// 11-digit CPF, an 11-digit PIS, a 15-digit RG and a 6-byte órgão emissor.
+func splitTitularPF(oid, value string) (dob time.Time, cpf, pis, rg, emissor string, err error) {
+	const wantLen = 8 + 11 + 11 + 15 + 6
+	if len(value) != wantLen {
+		return time.Time{}, "", "", "", "", &ICPBrasilFieldError{
+			OID:    oid,
+			Reason: fmt.Sprintf("want %d bytes, got %d", wantLen, len(value)),
+		}
+	}
+
+	dob, err = time.Parse("02012006", value[0:8])
+	if err != nil {
+		return time.Time{}, "", "", "", "", &ICPBrasilFieldError{OID: oid, Reason: fmt.Sprintf("invalid date of birth: %s", err)}
+	}
+	cpf = value[8:19]
+	pis = value[19:30]
+	rg = value[30:45]
+	emissor = value[45:51]
+
+	if !isAllDigits(cpf) {
+		return time.Time{}, "", "", "", "", &ICPBrasilFieldError{OID: oid, Reason: fmt.Sprintf("CPF: want 11 digits, got %q", cpf)}
+	}
+	if !isAllDigits(pis) {
+		return time.Time{}, "", "", "", "", &ICPBrasilFieldError{OID: oid, Reason: fmt.Sprintf("PIS: want 11 digits, got %q", pis)}
+	}
+
+	return dob, cpf, pis, rg, emissor, nil
+}
+
+// ExtractICPBrasilIdentifiers walks the SubjectAlternativeName extension of
+// cert and decodes the ICP-Brasil OtherName values that Open Banking Brasil
+// issuer profiles embed for PF and PJ certificates. Values that don't
+// match their OID's fixed-width layout are reported via an
+// *ICPBrasilFieldError rather than being silently dropped; a value that
+// doesn't decode as an ASN.1 string is reported as a plain error.
+func ExtractICPBrasilIdentifiers(cert *x509.Certificate) (*ICPBrasilIdentifiers, error) {
+	var sanValue []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.String() == oidSubjectAltName {
+			sanValue = ext.Value
+			break
+		}
+	}
+	if sanValue == nil {
+		return nil, errors.New("obbsubjectextractor: certificate has no SubjectAlternativeName extension")
+	}
+
+	raw := cryptobyte.String(sanValue)
+	if !raw.ReadASN1(&raw, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("obbsubjectextractor: invalid SubjectAltName: invalid GeneralNames")
+	}
+
+	ids := &ICPBrasilIdentifiers{}
+
+	for !raw.Empty() {
+		var name cryptobyte.String
+		var tag cryptobyte_asn1.Tag
+		if !raw.ReadAnyASN1(&name, &tag) {
+			return nil, errors.New("obbsubjectextractor: invalid SubjectAltName: invalid GeneralName")
+		}
+		if tag != otherNameTag {
+			// Not an otherName (rfc822Name, dNSName, ...); ICP-Brasil
+			// identifiers are only carried in otherName values.
+			continue
+		}
+
+		var oid asn1.ObjectIdentifier
+		if !name.ReadASN1ObjectIdentifier(&oid) {
+			return nil, errors.New("obbsubjectextractor: invalid SubjectAltName: invalid otherName type-id")
+		}
+		oidStr := oid.String()
+
+		var explicit cryptobyte.String
+		if !name.ReadASN1(&explicit, otherNameTag) {
+			return nil, fmt.Errorf("obbsubjectextractor: invalid SubjectAltName: otherName %s: missing value", oidStr)
+		}
+		var valueTag cryptobyte_asn1.Tag
+		var valueBytes cryptobyte.String
+		if !explicit.ReadAnyASN1(&valueBytes, &valueTag) {
+			return nil, fmt.Errorf("obbsubjectextractor: invalid SubjectAltName: otherName %s: invalid value", oidStr)
+		}
+		value, err := parseASN1String(valueTag, valueBytes)
+		if err != nil {
+			return nil, fmt.Errorf("obbsubjectextractor: invalid SubjectAltName: otherName %s: %s", oidStr, err)
+		}
+
+		switch oidStr {
+		case oidICPBrasilTitularPF:
+			ids.DateOfBirth, ids.CPF, ids.PIS, ids.RG, ids.RGEmissor, err = splitTitularPF(oidStr, value)
+		case oidICPBrasilResponsavelPJNome:
+			ids.ResponsavelNome = value
+		case oidICPBrasilCNPJ:
+			if len(value) != 14 {
+				err = &ICPBrasilFieldError{OID: oidStr, Reason: fmt.Sprintf("want 14 digits, got %d", len(value))}
+			} else if !isAllDigits(value) {
+				err = &ICPBrasilFieldError{OID: oidStr, Reason: fmt.Sprintf("want 14 digits, got %q", value)}
+			} else {
+				ids.CNPJ = value
+			}
+		case oidICPBrasilResponsavelPJDados:
+			ids.ResponsavelDateOfBirth, ids.ResponsavelCPF, ids.ResponsavelPIS, ids.ResponsavelRG, ids.ResponsavelRGEmissor, err = splitTitularPF(oidStr, value)
+		case oidICPBrasilTituloEleitor:
+			if len(value) != 12 {
+				err = &ICPBrasilFieldError{OID: oidStr, Reason: fmt.Sprintf("want 12 digits, got %d", len(value))}
+			} else if !isAllDigits(value) {
+				err = &ICPBrasilFieldError{OID: oidStr, Reason: fmt.Sprintf("want 12 digits, got %q", value)}
+			} else {
+				ids.VoterID = value
+			}
+		case oidICPBrasilCEIPJ:
+			if len(value) != 12 {
+				err = &ICPBrasilFieldError{OID: oidStr, Reason: fmt.Sprintf("want 12 digits, got %d", len(value))}
+			} else if !isAllDigits(value) {
+				err = &ICPBrasilFieldError{OID: oidStr, Reason: fmt.Sprintf("want 12 digits, got %q", value)}
+			} else {
+				ids.CEIPJ = value
+			}
+		case oidICPBrasilCEIPF:
+			if len(value) != 12 {
+				err = &ICPBrasilFieldError{OID: oidStr, Reason: fmt.Sprintf("want 12 digits, got %d", len(value))}
+			} else if !isAllDigits(value) {
+				err = &ICPBrasilFieldError{OID: oidStr, Reason: fmt.Sprintf("want 12 digits, got %q", value)}
+			} else {
+				ids.CEIPF = value
+			}
+		default:
+			// Unknown otherName OID; ignore, it is not an ICP-Brasil identifier.
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
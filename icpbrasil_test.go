@@ -0,0 +1,203 @@
+package obbsubjectextractor
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func TestSplitTitularPF(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string // substring expected in err.Error(), "" if no error expected
+	}{
+		{
+			name:  "valid",
+			value: "01011990" + "12345678901" + "12345678901" + "123456789012345" + "ABCDEF",
+		},
+		{
+			name:    "wrong length",
+			value:   "0101199012345678901",
+			wantErr: "want 51 bytes, got 19",
+		},
+		{
+			name:    "invalid date of birth",
+			value:   "99999999" + "12345678901" + "12345678901" + "123456789012345" + "ABCDEF",
+			wantErr: "invalid date of birth",
+		},
+		{
+			name:    "non-numeric CPF",
+			value:   "01011990" + "ABCDEFGHIJK" + "12345678901" + "123456789012345" + "ABCDEF",
+			wantErr: "CPF: want 11 digits",
+		},
+		{
+			name:    "non-numeric PIS",
+			value:   "01011990" + "12345678901" + "ABCDEFGHIJK" + "123456789012345" + "ABCDEF",
+			wantErr: "PIS: want 11 digits",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dob, cpf, pis, rg, emissor, err := splitTitularPF("2.16.76.1.3.1", tt.value)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("splitTitularPF(%q) = nil error, want one containing %q", tt.value, tt.wantErr)
+				}
+				if got := err.Error(); !strings.Contains(got, tt.wantErr) {
+					t.Fatalf("splitTitularPF(%q) error = %q, want containing %q", tt.value, got, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitTitularPF(%q) unexpected error: %v", tt.value, err)
+			}
+			wantDOB := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+			if !dob.Equal(wantDOB) {
+				t.Errorf("dob = %v, want %v", dob, wantDOB)
+			}
+			if cpf != "12345678901" {
+				t.Errorf("cpf = %q, want %q", cpf, "12345678901")
+			}
+			if pis != "12345678901" {
+				t.Errorf("pis = %q, want %q", pis, "12345678901")
+			}
+			if rg != "123456789012345" {
+				t.Errorf("rg = %q, want %q", rg, "123456789012345")
+			}
+			if emissor != "ABCDEF" {
+				t.Errorf("emissor = %q, want %q", emissor, "ABCDEF")
+			}
+		})
+	}
+}
+
+func TestIsAllDigits(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"12345678901234", true},
+		{"AAAAAAAAAAAAAA", false},
+		{"1234567890123A", false},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if got := isAllDigits(tt.value); got != tt.want {
+			t.Errorf("isAllDigits(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// buildOtherName encodes a single otherName GeneralName carrying value
+// under valueTag, the way ICP-Brasil issuers embed their identifiers.
+func buildOtherName(t *testing.T, oid asn1.ObjectIdentifier, valueTag cryptobyte_asn1.Tag, value string) []byte {
+	t.Helper()
+	b := cryptobyte.NewBuilder(nil)
+	b.AddASN1(otherNameTag, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(oid)
+		b.AddASN1(otherNameTag, func(b *cryptobyte.Builder) {
+			b.AddASN1(valueTag, func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(value))
+			})
+		})
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		t.Fatalf("building otherName: %v", err)
+	}
+	return out
+}
+
+func certWithSAN(t *testing.T, entries ...[]byte) *x509.Certificate {
+	t.Helper()
+	b := cryptobyte.NewBuilder(nil)
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for _, e := range entries {
+			b.AddBytes(e)
+		}
+	})
+	sanValue, err := b.Bytes()
+	if err != nil {
+		t.Fatalf("building SubjectAltName: %v", err)
+	}
+	return &x509.Certificate{
+		Extensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Value: sanValue},
+		},
+	}
+}
+
+func TestExtractICPBrasilIdentifiers(t *testing.T) {
+	cnpjOID := asn1.ObjectIdentifier{2, 16, 76, 1, 3, 3}
+
+	t.Run("valid CNPJ", func(t *testing.T) {
+		cert := certWithSAN(t, buildOtherName(t, cnpjOID, cryptobyte_asn1.PrintableString, "12345678901234"))
+		ids, err := ExtractICPBrasilIdentifiers(cert)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids.CNPJ != "12345678901234" {
+			t.Errorf("CNPJ = %q, want %q", ids.CNPJ, "12345678901234")
+		}
+	})
+
+	t.Run("non-numeric CNPJ rejected", func(t *testing.T) {
+		cert := certWithSAN(t, buildOtherName(t, cnpjOID, cryptobyte_asn1.PrintableString, "AAAAAAAAAAAAAA"))
+		_, err := ExtractICPBrasilIdentifiers(cert)
+		if err == nil {
+			t.Fatal("expected error for non-numeric CNPJ, got nil")
+		}
+		var fieldErr *ICPBrasilFieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("error = %v, want *ICPBrasilFieldError", err)
+		}
+	})
+
+	t.Run("no SAN extension", func(t *testing.T) {
+		cert := &x509.Certificate{}
+		if _, err := ExtractICPBrasilIdentifiers(cert); err == nil {
+			t.Fatal("expected error for missing SubjectAltName extension")
+		}
+	})
+
+	voterIDOID := asn1.ObjectIdentifier{2, 16, 76, 1, 3, 5}
+
+	t.Run("valid VoterID", func(t *testing.T) {
+		cert := certWithSAN(t, buildOtherName(t, voterIDOID, cryptobyte_asn1.PrintableString, "123456789012"))
+		ids, err := ExtractICPBrasilIdentifiers(cert)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids.VoterID != "123456789012" {
+			t.Errorf("VoterID = %q, want %q", ids.VoterID, "123456789012")
+		}
+	})
+
+	t.Run("non-numeric VoterID rejected", func(t *testing.T) {
+		cert := certWithSAN(t, buildOtherName(t, voterIDOID, cryptobyte_asn1.PrintableString, "AAAAAAAAAAAA"))
+		_, err := ExtractICPBrasilIdentifiers(cert)
+		if err == nil {
+			t.Fatal("expected error for non-numeric VoterID, got nil")
+		}
+		var fieldErr *ICPBrasilFieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("error = %v, want *ICPBrasilFieldError", err)
+		}
+	})
+
+	t.Run("wrong length VoterID rejected", func(t *testing.T) {
+		cert := certWithSAN(t, buildOtherName(t, voterIDOID, cryptobyte_asn1.PrintableString, "12345"))
+		if _, err := ExtractICPBrasilIdentifiers(cert); err == nil {
+			t.Fatal("expected error for wrong-length VoterID, got nil")
+		}
+	})
+}
@@ -0,0 +1,300 @@
+// Package parser decodes X.509 certificates directly with
+// golang.org/x/crypto/cryptobyte, the way Go's own crypto/x509 rewrote its
+// parser. Unlike crypto/x509.ParseCertificate, it is built for leniency
+// rather than speed: several certificates issued under ICP-Brasil use
+// encodings the stdlib parser rejects outright (negative serial numbers,
+// non-DER BOOLEANs in extensions, 8-bit TeletexString issuers, malformed
+// AIA URLs), and Open Banking tooling still has to extract a DN out of
+// them.
+package parser
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/esachser/obbsubjectextractor"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Certificate mirrors the subset of crypto/x509.Certificate that
+// obbsubjectextractor and its callers need. Fields that could not be
+// decoded because of a non-conformant encoding are left at their zero
+// value rather than aborting the parse; see Warnings.
+type Certificate struct {
+	Raw               []byte
+	RawTBSCertificate []byte
+
+	Version      int
+	SerialNumber *big.Int
+
+	RawIssuer []byte
+	Issuer    *obbsubjectextractor.DistinguishedName
+
+	NotBefore, NotAfter time.Time
+
+	RawSubject []byte
+	Subject    *obbsubjectextractor.DistinguishedName
+
+	RawSubjectPublicKeyInfo []byte
+	PublicKeyAlgorithm      asn1.ObjectIdentifier
+
+	Extensions []pkix.Extension
+
+	// Warnings records non-fatal problems found while decoding a
+	// non-conformant certificate. A field that could not be decoded is
+	// left at its zero value rather than guessed at; a negative serial
+	// number is warned about and kept in SerialNumber.
+	Warnings []error
+}
+
+// ParseCertificate decodes the DER encoding of an X.509 certificate. It
+// returns an error when a mandatory field's ASN.1 framing (e.g. a missing
+// or malformed signature algorithm, issuer, validity, subject or SPKI
+// SEQUENCE, or extensions [3] wrapper) makes it impossible to keep walking
+// the TBSCertificate. Within that structural skeleton, a negative serial
+// number, an undecodable Name, validity time, SPKI algorithm or
+// extensions list is recorded as a warning on the returned Certificate
+// instead of aborting the parse. A non-DER BOOLEAN critical flag is
+// accepted without a warning, and a Name attribute value that doesn't
+// decode under its declared ASN.1 string type is kept undecoded in the
+// parsed DistinguishedName (see obbsubjectextractor.ParseName).
+func ParseCertificate(der []byte) (*Certificate, error) {
+	input := cryptobyte.String(der)
+
+	var cbs cryptobyte.String
+	if !input.ReadASN1(&cbs, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("parser: invalid certificate (could not read outer SEQUENCE)")
+	}
+
+	cert := &Certificate{Raw: der}
+
+	var tbs cryptobyte.String
+	if !cbs.ReadASN1Element(&tbs, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("parser: invalid certificate (could not read TBSCertificate)")
+	}
+	cert.RawTBSCertificate = []byte(tbs)
+	if !tbs.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("parser: invalid certificate (could not unwrap TBSCertificate)")
+	}
+
+	if err := cert.parseTBSCertificate(tbs); err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+func (c *Certificate) warnf(format string, args ...interface{}) {
+	c.Warnings = append(c.Warnings, fmt.Errorf(format, args...))
+}
+
+func (c *Certificate) parseTBSCertificate(tbs cryptobyte.String) error {
+	var version int
+	if !tbs.ReadOptionalASN1Integer(&version, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), 0) {
+		return errors.New("parser: malformed TBSCertificate version")
+	}
+	// The ASN.1 Version enumeration is 0-indexed (v1=0, v2=1, v3=2); mirror
+	// crypto/x509.Certificate.Version, which reports the 1-indexed form.
+	c.Version = version + 1
+
+	serial := new(big.Int)
+	if !tbs.ReadASN1Integer(serial) {
+		return errors.New("parser: malformed TBSCertificate serial number")
+	}
+	if serial.Sign() < 0 {
+		// CA/Browser Forum baseline requirements forbid negative serial
+		// numbers, and crypto/x509 rejects them; ICP-Brasil certificates
+		// exist that carry one anyway, so keep the value and just flag it.
+		c.warnf("parser: certificate has a negative serial number")
+	}
+	c.SerialNumber = serial
+
+	// signature AlgorithmIdentifier; the inner fields aren't needed here,
+	// only skipped over.
+	var sigAlgSeq cryptobyte.String
+	if !tbs.ReadASN1(&sigAlgSeq, cryptobyte_asn1.SEQUENCE) {
+		return errors.New("parser: malformed TBSCertificate signature algorithm")
+	}
+
+	var issuer cryptobyte.String
+	if !tbs.ReadASN1Element(&issuer, cryptobyte_asn1.SEQUENCE) {
+		return errors.New("parser: malformed TBSCertificate issuer")
+	}
+	c.RawIssuer = []byte(issuer)
+	if dn, err := obbsubjectextractor.ParseName(c.RawIssuer); err != nil {
+		c.warnf("parser: malformed issuer: %s", err)
+	} else {
+		c.Issuer = dn
+	}
+
+	var validity cryptobyte.String
+	if !tbs.ReadASN1(&validity, cryptobyte_asn1.SEQUENCE) {
+		return errors.New("parser: malformed TBSCertificate validity")
+	}
+	notBefore, err := readTime(&validity)
+	if err != nil {
+		c.warnf("parser: malformed notBefore: %s", err)
+	} else {
+		c.NotBefore = notBefore
+	}
+	notAfter, err := readTime(&validity)
+	if err != nil {
+		c.warnf("parser: malformed notAfter: %s", err)
+	} else {
+		c.NotAfter = notAfter
+	}
+
+	var subject cryptobyte.String
+	if !tbs.ReadASN1Element(&subject, cryptobyte_asn1.SEQUENCE) {
+		return errors.New("parser: malformed TBSCertificate subject")
+	}
+	c.RawSubject = []byte(subject)
+	if dn, err := obbsubjectextractor.ParseName(c.RawSubject); err != nil {
+		c.warnf("parser: malformed subject: %s", err)
+	} else {
+		c.Subject = dn
+	}
+
+	var spki cryptobyte.String
+	if !tbs.ReadASN1Element(&spki, cryptobyte_asn1.SEQUENCE) {
+		return errors.New("parser: malformed TBSCertificate subjectPublicKeyInfo")
+	}
+	c.RawSubjectPublicKeyInfo = []byte(spki)
+	if oid, err := readSPKIAlgorithm(spki); err != nil {
+		c.warnf("parser: malformed subjectPublicKeyInfo: %s", err)
+	} else {
+		c.PublicKeyAlgorithm = oid
+	}
+
+	// issuerUniqueID [1] and subjectUniqueID [2] are not exposed on
+	// Certificate; skip over them if present.
+	if !tbs.SkipOptionalASN1(cryptobyte_asn1.Tag(1).ContextSpecific()) {
+		return errors.New("parser: malformed issuerUniqueID")
+	}
+	if !tbs.SkipOptionalASN1(cryptobyte_asn1.Tag(2).ContextSpecific()) {
+		return errors.New("parser: malformed subjectUniqueID")
+	}
+
+	var extensions cryptobyte.String
+	var hasExtensions bool
+	if !tbs.ReadOptionalASN1(&extensions, &hasExtensions, cryptobyte_asn1.Tag(3).Constructed().ContextSpecific()) {
+		return errors.New("parser: malformed extensions")
+	}
+	if hasExtensions {
+		if err := c.parseExtensions(extensions); err != nil {
+			c.warnf("parser: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func readSPKIAlgorithm(spki cryptobyte.String) (asn1.ObjectIdentifier, error) {
+	var seq cryptobyte.String
+	if !spki.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("invalid SubjectPublicKeyInfo")
+	}
+	var algSeq cryptobyte.String
+	if !seq.ReadASN1(&algSeq, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("invalid AlgorithmIdentifier")
+	}
+	var oid asn1.ObjectIdentifier
+	if !algSeq.ReadASN1ObjectIdentifier(&oid) {
+		return nil, errors.New("invalid AlgorithmIdentifier OID")
+	}
+	return oid, nil
+}
+
+// readTime decodes a Time (UTCTime or GeneralizedTime) from the front of
+// validity.
+func readTime(validity *cryptobyte.String) (time.Time, error) {
+	var tag cryptobyte_asn1.Tag
+	var value cryptobyte.String
+	if !validity.ReadAnyASN1(&value, &tag) {
+		return time.Time{}, errors.New("invalid Time")
+	}
+
+	var format string
+	switch tag {
+	case cryptobyte_asn1.UTCTime:
+		format = "060102150405Z0700"
+	case cryptobyte_asn1.GeneralizedTime:
+		format = "20060102150405Z0700"
+	default:
+		return time.Time{}, fmt.Errorf("unsupported Time tag: %v", tag)
+	}
+
+	s := string(value)
+	if t, err := time.Parse(format, s); err == nil {
+		return t, nil
+	}
+	// Some non-conformant issuers omit the seconds from a UTCTime or put a
+	// four-digit-year value under the UTCTime tag; fall back to those
+	// variants before giving up.
+	for _, alt := range []string{"0601021504Z0700", "20060102150405Z"} {
+		if t, err := time.Parse(alt, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid Time value %q for tag %v", s, tag)
+}
+
+// readLenientBoolean decodes a BOOLEAN without crypto/x509's strict DER
+// check that the content octet be exactly 0x00 or 0xFF: several
+// ICP-Brasil issuers encode the extensions' "critical" field with other
+// non-zero octets, which is valid BER even if not valid DER.
+func readLenientBoolean(s *cryptobyte.String) (bool, bool, error) {
+	if !s.PeekASN1Tag(cryptobyte_asn1.BOOLEAN) {
+		return false, false, nil
+	}
+	var raw cryptobyte.String
+	if !s.ReadASN1(&raw, cryptobyte_asn1.BOOLEAN) {
+		return false, false, errors.New("invalid BOOLEAN")
+	}
+	if len(raw) != 1 {
+		return false, false, errors.New("invalid BOOLEAN length")
+	}
+	return raw[0] != 0, true, nil
+}
+
+func (c *Certificate) parseExtensions(extensions cryptobyte.String) error {
+	var seq cryptobyte.String
+	if !extensions.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+		return errors.New("malformed extensions SEQUENCE")
+	}
+
+	for !seq.Empty() {
+		var ext cryptobyte.String
+		if !seq.ReadASN1(&ext, cryptobyte_asn1.SEQUENCE) {
+			return errors.New("malformed extension")
+		}
+
+		var oid asn1.ObjectIdentifier
+		if !ext.ReadASN1ObjectIdentifier(&oid) {
+			return errors.New("malformed extension OID")
+		}
+
+		critical, _, err := readLenientBoolean(&ext)
+		if err != nil {
+			c.warnf("parser: malformed critical flag on extension %s: %s", oid, err)
+		}
+
+		var value cryptobyte.String
+		if !ext.ReadASN1(&value, cryptobyte_asn1.OCTET_STRING) {
+			return fmt.Errorf("malformed extnValue on extension %s", oid)
+		}
+
+		c.Extensions = append(c.Extensions, pkix.Extension{
+			Id:       oid,
+			Critical: critical,
+			Value:    []byte(value),
+		})
+	}
+
+	return nil
+}
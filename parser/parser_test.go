@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func TestReadTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     cryptobyte_asn1.Tag
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:  "UTCTime",
+			tag:   cryptobyte_asn1.UTCTime,
+			value: "230615120000Z",
+			want:  time.Date(2023, time.June, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "GeneralizedTime",
+			tag:   cryptobyte_asn1.GeneralizedTime,
+			value: "20230615120000Z",
+			want:  time.Date(2023, time.June, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "UTCTime without seconds, non-DER variant",
+			tag:   cryptobyte_asn1.UTCTime,
+			value: "2306151200Z",
+			want:  time.Date(2023, time.June, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "GeneralizedTime with numeric offset, non-DER variant",
+			tag:   cryptobyte_asn1.GeneralizedTime,
+			value: "20230615120000+0000",
+			want:  time.Date(2023, time.June, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "garbage value",
+			tag:     cryptobyte_asn1.UTCTime,
+			value:   "not-a-time",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := cryptobyte.NewBuilder(nil)
+			b.AddASN1(tt.tag, func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(tt.value))
+			})
+			der, err := b.Bytes()
+			if err != nil {
+				t.Fatalf("building Time: %v", err)
+			}
+			s := cryptobyte.String(der)
+			got, err := readTime(&s)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readTime(%q) = nil error, want one", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readTime(%q): %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("readTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLenientBoolean(t *testing.T) {
+	tests := []struct {
+		name      string
+		der       []byte
+		wantOK    bool
+		wantValue bool
+		wantErr   bool
+	}{
+		{name: "absent", der: nil, wantOK: false},
+		{name: "DER true", der: []byte{0x01, 0x01, 0xff}, wantOK: true, wantValue: true},
+		{name: "DER false", der: []byte{0x01, 0x01, 0x00}, wantOK: true, wantValue: false},
+		{name: "non-DER true", der: []byte{0x01, 0x01, 0x02}, wantOK: true, wantValue: true},
+		{name: "wrong length", der: []byte{0x01, 0x02, 0x01, 0x00}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := cryptobyte.String(tt.der)
+			value, ok, err := readLenientBoolean(&s)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readLenientBoolean(% x) = nil error, want one", tt.der)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readLenientBoolean(% x): %v", tt.der, err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && value != tt.wantValue {
+				t.Errorf("value = %v, want %v", value, tt.wantValue)
+			}
+		})
+	}
+}
+
+// buildName encodes a minimal single-RDN Name, enough to satisfy
+// obbsubjectextractor.ParseName.
+func buildName(t *testing.T, cn string) []byte {
+	t.Helper()
+	b := cryptobyte.NewBuilder(nil)
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) { // RDNSequence
+		b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1ObjectIdentifier([]int{2, 5, 4, 3}) // CN
+				b.AddASN1(cryptobyte_asn1.PrintableString, func(b *cryptobyte.Builder) {
+					b.AddBytes([]byte(cn))
+				})
+			})
+		})
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		t.Fatalf("building Name: %v", err)
+	}
+	return out
+}
+
+// buildCertificate assembles a minimal DER certificate whose TBSCertificate
+// carries serial (possibly negative) and a single extension with the given
+// raw (possibly non-DER) critical BOOLEAN encoding.
+func buildCertificate(t *testing.T, serial *big.Int, rawCritical []byte) []byte {
+	t.Helper()
+	issuer := buildName(t, "Test CA")
+	subject := buildName(t, "Test Leaf")
+
+	b := cryptobyte.NewBuilder(nil)
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) { // Certificate
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) { // TBSCertificate
+			b.AddASN1BigInt(serial)
+
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) { // signature AlgorithmIdentifier
+				b.AddASN1ObjectIdentifier([]int{1, 2, 840, 113549, 1, 1, 11})
+			})
+
+			b.AddBytes(issuer)
+
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) { // Validity
+				b.AddASN1(cryptobyte_asn1.UTCTime, func(b *cryptobyte.Builder) {
+					b.AddBytes([]byte("230101000000Z"))
+				})
+				b.AddASN1(cryptobyte_asn1.UTCTime, func(b *cryptobyte.Builder) {
+					b.AddBytes([]byte("240101000000Z"))
+				})
+			})
+
+			b.AddBytes(subject)
+
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) { // SubjectPublicKeyInfo
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1ObjectIdentifier([]int{1, 2, 840, 113549, 1, 1, 1})
+				})
+				b.AddASN1BitString([]byte{0x00})
+			})
+
+			b.AddASN1(cryptobyte_asn1.Tag(3).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) { // extensions
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) { // Extension
+						b.AddASN1ObjectIdentifier([]int{2, 5, 29, 19}) // basicConstraints
+						b.AddBytes(rawCritical)
+						b.AddASN1(cryptobyte_asn1.OCTET_STRING, func(b *cryptobyte.Builder) {
+							b.AddBytes([]byte{0x30, 0x00})
+						})
+					})
+				})
+			})
+		})
+	})
+
+	out, err := b.Bytes()
+	if err != nil {
+		t.Fatalf("building Certificate: %v", err)
+	}
+	return out
+}
+
+func TestParseCertificateLeniencies(t *testing.T) {
+	// A non-DER critical BOOLEAN (content octet 0x02 instead of 0x00/0xFF)
+	// and a negative serial number should both be accepted, not rejected
+	// outright; only the negative serial number is recorded as a warning.
+	nonDERCritical := []byte{0x01, 0x01, 0x02}
+	der := buildCertificate(t, big.NewInt(-1), nonDERCritical)
+
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	if cert.SerialNumber.Sign() >= 0 {
+		t.Errorf("SerialNumber = %v, want negative", cert.SerialNumber)
+	}
+	if len(cert.Extensions) != 1 || !cert.Extensions[0].Critical {
+		t.Fatalf("Extensions = %+v, want one critical extension", cert.Extensions)
+	}
+	if len(cert.Warnings) != 1 {
+		t.Fatalf("Warnings = %v, want exactly 1 (negative serial number)", cert.Warnings)
+	}
+	if !strings.Contains(cert.Warnings[0].Error(), "negative serial number") {
+		t.Errorf("Warnings[0] = %q, want it to mention the negative serial number", cert.Warnings[0])
+	}
+	if cert.Version != 1 {
+		t.Errorf("Version = %d, want 1 (v1, absent version field defaults to 0-indexed 0)", cert.Version)
+	}
+}
@@ -0,0 +1,104 @@
+package obbsubjectextractor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Policy formats a parsed DistinguishedName according to a specific Open
+// Banking jurisdiction's Subject DN certificate profile.
+type Policy interface {
+	Format(dn *DistinguishedName) (string, error)
+}
+
+// BrasilPolicy implements the Open Banking Brasil Subject DN convention:
+// ExtractSubject's historical reversed-RDN, known-short-name form, with an
+// "<oid>=#<hex>" fallback for attributes KnownOIDs doesn't recognize.
+type BrasilPolicy struct{}
+
+// Format implements Policy.
+func (BrasilPolicy) Format(dn *DistinguishedName) (string, error) {
+	return dn.String(RenderOptions{Mode: ModeBrasil})
+}
+
+var ukOBIENames = map[string]string{
+	"2.5.4.3":  "CN",
+	"2.5.4.10": "O",
+	"2.5.4.11": "OU",
+	"2.5.4.6":  "C",
+	"2.5.4.5":  "serialNumber",
+	"2.5.4.97": "organizationIdentifier",
+}
+
+// UKOBIEPolicy implements the UK Open Banking (OBIE) certificate profile:
+// forward RFC 4514 order, requiring both organizationIdentifier
+// (2.5.4.97) and serialNumber (2.5.4.5), and rejecting any attribute the
+// profile does not recognize.
+type UKOBIEPolicy struct{}
+
+// Format implements Policy.
+func (UKOBIEPolicy) Format(dn *DistinguishedName) (string, error) {
+	var hasOrgID, hasSerial bool
+	for _, rdn := range dn.RDNs {
+		for _, ava := range rdn.AVAs {
+			t := ava.Type.String()
+			if _, ok := ukOBIENames[t]; !ok {
+				return "", fmt.Errorf("obbsubjectextractor: UK OBIE policy: unsupported attribute %s", t)
+			}
+			switch t {
+			case "2.5.4.97":
+				hasOrgID = true
+			case "2.5.4.5":
+				hasSerial = true
+			}
+		}
+	}
+	if !hasOrgID {
+		return "", errors.New("obbsubjectextractor: UK OBIE policy: missing required organizationIdentifier (2.5.4.97)")
+	}
+	if !hasSerial {
+		return "", errors.New("obbsubjectextractor: UK OBIE policy: missing required serialNumber (2.5.4.5)")
+	}
+	return dn.render(RenderOptions{Mode: ModeRFC4514}, ukOBIENames)
+}
+
+var etsiNames = map[string]string{
+	"2.5.4.3":  "CN",
+	"2.5.4.10": "O",
+	"2.5.4.11": "OU",
+	"2.5.4.6":  "C",
+	"2.5.4.97": "organizationIdentifier",
+}
+
+// etsiOrgIDPrefix matches the ETSI EN 319 412 organizationIdentifier
+// prefixes used by eIDAS/QWAC certificates: VATxx- for a VAT registration,
+// NTRxx- for a national trade register number, PSDxx- for a PSD2 authority
+// identifier, where xx is an ISO 3166-1 alpha-2 country code.
+var etsiOrgIDPrefix = regexp.MustCompile(`^(VAT|NTR|PSD)[A-Z]{2}-`)
+
+// ETSIPolicy implements the eIDAS/QWAC (ETSI EN 319 412) certificate
+// profile: it requires organizationIdentifier (2.5.4.97) and validates
+// that its value carries one of the VATxx-/NTRxx-/PSDxx- prefixes.
+type ETSIPolicy struct{}
+
+// Format implements Policy.
+func (ETSIPolicy) Format(dn *DistinguishedName) (string, error) {
+	var orgID string
+	var hasOrgID bool
+	for _, rdn := range dn.RDNs {
+		for _, ava := range rdn.AVAs {
+			if ava.Type.String() == "2.5.4.97" {
+				hasOrgID = true
+				orgID = ava.Value
+			}
+		}
+	}
+	if !hasOrgID {
+		return "", errors.New("obbsubjectextractor: ETSI policy: missing required organizationIdentifier (2.5.4.97)")
+	}
+	if !etsiOrgIDPrefix.MatchString(orgID) {
+		return "", fmt.Errorf("obbsubjectextractor: ETSI policy: organizationIdentifier %q has no VATxx-/NTRxx-/PSDxx- prefix", orgID)
+	}
+	return dn.render(RenderOptions{Mode: ModeRFC4514}, etsiNames)
+}
@@ -0,0 +1,159 @@
+package obbsubjectextractor
+
+import (
+	"encoding/asn1"
+	"strings"
+	"testing"
+
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+	oidCN     = asn1.ObjectIdentifier{2, 5, 4, 3}
+	oidO      = asn1.ObjectIdentifier{2, 5, 4, 10}
+	oidSerial = asn1.ObjectIdentifier{2, 5, 4, 5}
+	oidOrgID  = asn1.ObjectIdentifier{2, 5, 4, 97}
+)
+
+func TestBrasilPolicy(t *testing.T) {
+	raw := buildName(t, [][]ava{
+		{{oid: oidO, tag: cryptobyte_asn1.PrintableString, value: "Example Bank"}},
+		{{oid: oidCN, tag: cryptobyte_asn1.PrintableString, value: "api.example.com"}},
+	})
+	dn, err := ParseName(raw)
+	if err != nil {
+		t.Fatalf("ParseName: %v", err)
+	}
+
+	got, err := BrasilPolicy{}.Format(dn)
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	if want := "CN=api.example.com,O=Example Bank"; got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+}
+
+func TestUKOBIEPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		rdns    [][]ava
+		want    string
+		wantErr string
+	}{
+		{
+			name: "missing organizationIdentifier",
+			rdns: [][]ava{
+				{{oid: oidSerial, tag: cryptobyte_asn1.PrintableString, value: "12345"}},
+			},
+			wantErr: "missing required organizationIdentifier",
+		},
+		{
+			name: "missing serialNumber",
+			rdns: [][]ava{
+				{{oid: oidOrgID, tag: cryptobyte_asn1.PrintableString, value: "PSDGB-FCA-123456"}},
+			},
+			wantErr: "missing required serialNumber",
+		},
+		{
+			name: "unsupported attribute",
+			rdns: [][]ava{
+				{{oid: oidOrgID, tag: cryptobyte_asn1.PrintableString, value: "PSDGB-FCA-123456"}},
+				{{oid: oidSerial, tag: cryptobyte_asn1.PrintableString, value: "12345"}},
+				{{oid: asn1.ObjectIdentifier{2, 5, 4, 9}, tag: cryptobyte_asn1.PrintableString, value: "street"}},
+			},
+			wantErr: "unsupported attribute",
+		},
+		{
+			name: "valid",
+			rdns: [][]ava{
+				{{oid: oidOrgID, tag: cryptobyte_asn1.PrintableString, value: "PSDGB-FCA-123456"}},
+				{{oid: oidSerial, tag: cryptobyte_asn1.PrintableString, value: "12345"}},
+			},
+			want: "organizationIdentifier=PSDGB-FCA-123456,serialNumber=12345",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := buildName(t, tt.rdns)
+			dn, err := ParseName(raw)
+			if err != nil {
+				t.Fatalf("ParseName: %v", err)
+			}
+			got, err := UKOBIEPolicy{}.Format(dn)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("Format() = nil error, want one containing %q", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Format() error = %q, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestETSIPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		rdns    [][]ava
+		want    string
+		wantErr string
+	}{
+		{
+			name: "missing organizationIdentifier",
+			rdns: [][]ava{
+				{{oid: oidCN, tag: cryptobyte_asn1.PrintableString, value: "QWAC"}},
+			},
+			wantErr: "missing required organizationIdentifier",
+		},
+		{
+			name: "invalid prefix",
+			rdns: [][]ava{
+				{{oid: oidOrgID, tag: cryptobyte_asn1.PrintableString, value: "NOPE123456"}},
+			},
+			wantErr: "no VATxx-/NTRxx-/PSDxx- prefix",
+		},
+		{
+			name: "valid VAT prefix",
+			rdns: [][]ava{
+				{{oid: oidOrgID, tag: cryptobyte_asn1.PrintableString, value: "VATDE-123456789"}},
+			},
+			want: "organizationIdentifier=VATDE-123456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := buildName(t, tt.rdns)
+			dn, err := ParseName(raw)
+			if err != nil {
+				t.Fatalf("ParseName: %v", err)
+			}
+			got, err := ETSIPolicy{}.Format(dn)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("Format() = nil error, want one containing %q", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Format() error = %q, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
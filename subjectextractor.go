@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"unicode"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -97,7 +98,12 @@ func parseASN1String(tag cryptobyte_asn1.Tag, value []byte) (string, error) {
 	return "", fmt.Errorf("unsupported string type: %v", tag)
 }
 
-var oidNames = map[string]string{
+// KnownOIDs maps attribute type OIDs (dotted-decimal form) to the short
+// name (*DistinguishedName).String uses to render them. It is populated
+// with the attributes the Open Banking Brasil profile needs, but callers
+// are free to register additional ones (e.g. "2.5.4.5" for serialNumber,
+// "2.5.4.97" for organizationIdentifier) without forking this package.
+var KnownOIDs = map[string]string{
 	"2.5.4.3":                    "CN",
 	"2.5.4.7":                    "L",
 	"2.5.4.8":                    "ST",
@@ -109,86 +115,273 @@ var oidNames = map[string]string{
 	"0.9.2342.19200300.100.1.1":  "UID",
 }
 
-func parseName(raw cryptobyte.String) (string, error) {
-	if !raw.ReadASN1(&raw, cryptobyte_asn1.SEQUENCE) {
-		return "", errors.New("x509: invalid RDNSequence")
+// AttributeTypeAndValue is a single decoded attribute inside a
+// RelativeDistinguishedName. Value holds the decoded string whenever Tag is
+// one of the ASN.1 string types parseASN1String understands; Raw always
+// holds the undecoded content octets, so that values of an unrecognized or
+// undecodable type can still be rendered (e.g. as a "#hex" fallback).
+type AttributeTypeAndValue struct {
+	Type  asn1.ObjectIdentifier
+	Tag   cryptobyte_asn1.Tag
+	Value string
+	Raw   []byte
+}
+
+// RelativeDistinguishedName is one element of a DistinguishedName's RDN
+// sequence. It is usually a single AttributeTypeAndValue, but X.501 allows
+// an RDN to carry more than one (a "multi-valued RDN"); ParseName preserves
+// all of them, in the order they were encoded.
+type RelativeDistinguishedName struct {
+	AVAs []AttributeTypeAndValue
+}
+
+// DistinguishedName is a structured, typed representation of an X.501 Name,
+// such as a certificate's Subject or Issuer. Unlike the plain string
+// ExtractSubject returns, it lets callers inspect individual attributes
+// without re-parsing the rendered string.
+type DistinguishedName struct {
+	RDNs []RelativeDistinguishedName
+}
+
+// ParseName parses the DER encoding of an X.501 Name (an RDNSequence), such
+// as a certificate's RawSubject or RawIssuer, into a DistinguishedName.
+// Attribute values of a recognized ASN.1 string type are decoded into
+// AttributeTypeAndValue.Value; values of any other type, or that don't
+// decode under their declared type, are left with an empty Value but keep
+// their Raw content octets, so ParseName itself never fails because of an
+// unfamiliar value encoding. Rendering such a value under a known name
+// does fail (see String).
+func ParseName(raw []byte) (*DistinguishedName, error) {
+	s := cryptobyte.String(raw)
+	if !s.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("x509: invalid RDNSequence")
 	}
 
-	s := ""
+	dn := &DistinguishedName{}
 
-	for !raw.Empty() {
-		// var rdnSet pkix.RelativeDistinguishedNameSET
+	for !s.Empty() {
 		var set cryptobyte.String
-		if !raw.ReadASN1(&set, cryptobyte_asn1.SET) {
-			return "", errors.New("x509: invalid RDNSequence")
+		if !s.ReadASN1(&set, cryptobyte_asn1.SET) {
+			return nil, errors.New("x509: invalid RDNSequence")
 		}
+
+		var rdn RelativeDistinguishedName
 		for !set.Empty() {
 			var atav cryptobyte.String
 			if !set.ReadASN1(&atav, cryptobyte_asn1.SEQUENCE) {
-				return "", errors.New("x509: invalid RDNSequence: invalid attribute")
+				return nil, errors.New("x509: invalid RDNSequence: invalid attribute")
 			}
 			var attr pkix.AttributeTypeAndValue
 			if !atav.ReadASN1ObjectIdentifier(&attr.Type) {
-				return "", errors.New("x509: invalid RDNSequence: invalid attribute type")
+				return nil, errors.New("x509: invalid RDNSequence: invalid attribute type")
 			}
 			var rawValue cryptobyte.String
 			var valueTag cryptobyte_asn1.Tag
 			if !atav.ReadAnyASN1(&rawValue, &valueTag) {
-				return "", errors.New("x509: invalid RDNSequence: invalid attribute value")
+				return nil, errors.New("x509: invalid RDNSequence: invalid attribute value")
 			}
-			t := attr.Type.String()
-			if name, f := oidNames[t]; f {
-				valueString, err := parseASN1String(valueTag, rawValue)
-				if err != nil {
-					return "", fmt.Errorf("x509: invalid RDNSequence: invalid attribute value: %s", err)
-				}
-				escaped := make([]rune, 0, len(valueString))
-				for k, c := range valueString {
-					escape := false
-
-					switch c {
-					case ',', '+', '"', '\\', '<', '>', ';':
-						escape = true
-
-					case ' ':
-						escape = k == 0 || k == len(valueString)-1
-
-					case '#':
-						escape = k == 0
-					}
-
-					if escape {
-						escaped = append(escaped, '\\', c)
-					} else {
-						escaped = append(escaped, c)
-					}
-				}
-				s = name + "=" + string(escaped) + "," + s
-			} else {
-				bts := make([]byte, 0, len(rawValue)+2)
-				builder := cryptobyte.NewBuilder(bts)
-				builder.AddASN1(valueTag, func(child *cryptobyte.Builder) {
-					child.AddBytes(rawValue)
-				})
-
-				bts, err := builder.Bytes()
-				if err != nil {
-					return "", fmt.Errorf("x509: invalid RDNSequence: error building name: %s", err)
-				}
-				s = t + "=#" + hex.EncodeToString(bts) + "," + s
+
+			ava := AttributeTypeAndValue{Type: attr.Type, Tag: valueTag, Raw: []byte(rawValue)}
+			if value, err := parseASN1String(valueTag, rawValue); err == nil {
+				ava.Value = value
+			}
+			rdn.AVAs = append(rdn.AVAs, ava)
+		}
+		dn.RDNs = append(dn.RDNs, rdn)
+	}
+
+	return dn, nil
+}
+
+// ParseSubject parses cert's RawSubject into a DistinguishedName.
+func ParseSubject(cert *x509.Certificate) (*DistinguishedName, error) {
+	return ParseName(cert.RawSubject)
+}
+
+// RenderMode selects how (*DistinguishedName).String lays out RDNs and
+// escapes attribute values.
+type RenderMode int
+
+const (
+	// ModeBrasil reproduces the format ExtractSubject has always returned:
+	// RDNs most-specific-first, known attributes rendered by their
+	// KnownOIDs short name, unknown ones as "<oid>=#<hex>". The attributes
+	// of a multi-valued RDN are comma-separated like any other, in reverse
+	// of their encoded order.
+	ModeBrasil RenderMode = iota
+	// ModeRFC4514 renders RDNs in the order they appear in the ASN.1
+	// RDNSequence, joins multi-valued RDNs with "+", and escapes values
+	// per RFC 4514 (",+\"\\<>;" anywhere, leading/trailing space, a
+	// leading "#", and hex-escaping of NUL and other non-printable
+	// bytes).
+	ModeRFC4514
+	// ModeRFC2253 follows RFC 4514's predecessor, RFC 2253. It shares
+	// ModeRFC4514's ordering and escaping rules.
+	ModeRFC2253
+)
+
+// RenderOptions configures (*DistinguishedName).String.
+type RenderOptions struct {
+	Mode RenderMode
+}
+
+func escapeRFC4514(value string) string {
+	var b []byte
+	runes := []rune(value)
+	for k, c := range runes {
+		switch {
+		case c == ',' || c == '+' || c == '"' || c == '\\' || c == '<' || c == '>' || c == ';':
+			b = append(b, '\\', byte(c))
+		case c == ' ' && (k == 0 || k == len(runes)-1):
+			b = append(b, '\\', ' ')
+		case c == '#' && k == 0:
+			b = append(b, '\\', '#')
+		case c < 0x20 || c == 0x7f:
+			b = append(b, []byte(fmt.Sprintf("\\%02x", c))...)
+		default:
+			b = append(b, []byte(string(c))...)
+		}
+	}
+	return string(b)
+}
+
+func escapeBrasil(value string) string {
+	runes := []rune(value)
+	escaped := make([]rune, 0, len(runes))
+	for k, c := range runes {
+		escape := false
+
+		switch c {
+		case ',', '+', '"', '\\', '<', '>', ';':
+			escape = true
+
+		case ' ':
+			escape = k == 0 || k == len(runes)-1
+
+		case '#':
+			escape = k == 0
+		}
+
+		if escape {
+			escaped = append(escaped, '\\', c)
+		} else {
+			escaped = append(escaped, c)
+		}
+	}
+	return string(escaped)
+}
+
+// renderAVA renders a single attribute as "name=value", using names for
+// known OIDs and the "<oid>=#<hex>" fallback otherwise. A known OID whose
+// value doesn't decode under its declared ASN.1 string type is a hard
+// error, matching ExtractSubject's historical behavior: the "<oid>=#<hex>"
+// fallback is only for attributes the caller hasn't asked to be rendered
+// by name, not a way to paper over a malformed known attribute.
+func renderAVA(ava AttributeTypeAndValue, escape func(string) string, names map[string]string) (string, error) {
+	t := ava.Type.String()
+	if name, ok := names[t]; ok {
+		value, err := parseASN1String(ava.Tag, ava.Raw)
+		if err != nil {
+			return "", fmt.Errorf("x509: invalid RDNSequence: invalid attribute value: %s", err)
+		}
+		return name + "=" + escape(value), nil
+	}
+
+	bts := make([]byte, 0, len(ava.Raw)+2)
+	builder := cryptobyte.NewBuilder(bts)
+	builder.AddASN1(ava.Tag, func(child *cryptobyte.Builder) {
+		child.AddBytes(ava.Raw)
+	})
+	bts, err := builder.Bytes()
+	if err != nil {
+		return t + "=#" + hex.EncodeToString(ava.Raw), nil
+	}
+	return t + "=#" + hex.EncodeToString(bts), nil
+}
+
+// String renders the DistinguishedName per opts.Mode, using KnownOIDs for
+// attribute names. It returns an error if a known attribute's value
+// doesn't decode under its declared ASN.1 string type.
+func (dn *DistinguishedName) String(opts RenderOptions) (string, error) {
+	return dn.render(opts, KnownOIDs)
+}
+
+// render is String's implementation, parameterized over the OID-to-name
+// map so that Policy implementations can render against a profile-specific
+// attribute set without mutating the shared KnownOIDs map.
+func (dn *DistinguishedName) render(opts RenderOptions, names map[string]string) (string, error) {
+	escape := escapeBrasil
+	if opts.Mode == ModeRFC4514 || opts.Mode == ModeRFC2253 {
+		escape = escapeRFC4514
+	}
+
+	rdnStrings := make([]string, 0, len(dn.RDNs))
+	for _, rdn := range dn.RDNs {
+		avaStrings := make([]string, len(rdn.AVAs))
+		for j, ava := range rdn.AVAs {
+			s, err := renderAVA(ava, escape, names)
+			if err != nil {
+				return "", err
 			}
+			avaStrings[j] = s
+		}
+		if opts.Mode == ModeBrasil {
+			rdnStrings = append(rdnStrings, avaStrings...)
+		} else {
+			rdnStrings = append(rdnStrings, strings.Join(avaStrings, "+"))
 		}
 	}
 
-	if len(s) > 0 {
-		s = s[:len(s)-1]
+	if opts.Mode == ModeBrasil {
+		// Most-specific attribute first, with a multi-valued RDN's
+		// attributes split out, mirroring the historical behavior of
+		// ExtractSubject.
+		for i, j := 0, len(rdnStrings)-1; i < j; i, j = i+1, j-1 {
+			rdnStrings[i], rdnStrings[j] = rdnStrings[j], rdnStrings[i]
+		}
 	}
 
-	return s, nil
+	return strings.Join(rdnStrings, ","), nil
 }
 
 // ExtractSubject Returns the subject DN of certificate in accordance to Brazilian Security specs <https://openbanking-brasil.github.io/specs-seguranca>
+// It dispatches through the default BrasilPolicy; use ParseSubject with a
+// different Policy to target another jurisdiction's certificate profile.
 func ExtractSubject(cert *x509.Certificate) (string, error) {
-	s := cryptobyte.String(cert.RawSubject)
-	return parseName(s)
+	dn, err := ParseSubject(cert)
+	if err != nil {
+		return "", err
+	}
+	return BrasilPolicy{}.Format(dn)
+}
+
+// ExtractIssuer Returns the issuer DN of certificate in accordance to Brazilian Security specs <https://openbanking-brasil.github.io/specs-seguranca>
+func ExtractIssuer(cert *x509.Certificate) (string, error) {
+	dn, err := ParseName(cert.RawIssuer)
+	if err != nil {
+		return "", err
+	}
+	return dn.String(RenderOptions{Mode: ModeBrasil})
+}
+
+// ExtractSubjectFromCSR Returns the subject DN of a certificate signing
+// request in accordance to Brazilian Security specs
+// <https://openbanking-brasil.github.io/specs-seguranca>
+func ExtractSubjectFromCSR(csr *x509.CertificateRequest) (string, error) {
+	dn, err := ParseName(csr.RawSubject)
+	if err != nil {
+		return "", err
+	}
+	return dn.String(RenderOptions{Mode: ModeBrasil})
+}
+
+// ExtractIssuerFromCRL Returns the issuer DN of a certificate revocation
+// list in accordance to Brazilian Security specs
+// <https://openbanking-brasil.github.io/specs-seguranca>
+func ExtractIssuerFromCRL(crl *x509.RevocationList) (string, error) {
+	dn, err := ParseName(crl.RawIssuer)
+	if err != nil {
+		return "", err
+	}
+	return dn.String(RenderOptions{Mode: ModeBrasil})
 }
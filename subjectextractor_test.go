@@ -0,0 +1,139 @@
+package obbsubjectextractor
+
+import (
+	"encoding/asn1"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func TestEscapeRFC4514(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"special chars", `a,b+c"d\e<f>g;h`, `a\,b\+c\"d\\e\<f\>g\;h`},
+		{"leading space", " leading", `\ leading`},
+		{"trailing space", "trailing ", `trailing\ `},
+		{"interior space", "a b", "a b"},
+		{"leading hash", "#cafe", `\#cafe`},
+		{"non-leading hash", "a#b", "a#b"},
+		{"control char", "a\x01b", `a\01b`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeRFC4514(tt.value); got != tt.want {
+				t.Errorf("escapeRFC4514(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// ava describes a single attribute to encode into a test RDNSequence.
+type ava struct {
+	oid   asn1.ObjectIdentifier
+	tag   cryptobyte_asn1.Tag
+	value string
+}
+
+// buildName encodes rdns (each a slice of AVAs sharing one RDN) into a
+// DER RDNSequence, the way ParseName expects to read a certificate's
+// RawSubject or RawIssuer.
+func buildName(t *testing.T, rdns [][]ava) []byte {
+	t.Helper()
+	b := cryptobyte.NewBuilder(nil)
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for _, rdn := range rdns {
+			b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+				for _, a := range rdn {
+					b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						b.AddASN1ObjectIdentifier(a.oid)
+						b.AddASN1(a.tag, func(b *cryptobyte.Builder) {
+							b.AddBytes([]byte(a.value))
+						})
+					})
+				}
+			})
+		}
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		t.Fatalf("building Name: %v", err)
+	}
+	return out
+}
+
+func TestParseNameAndRender(t *testing.T) {
+	cn := asn1.ObjectIdentifier{2, 5, 4, 3}
+	o := asn1.ObjectIdentifier{2, 5, 4, 10}
+	unknown := asn1.ObjectIdentifier{1, 2, 3, 4}
+
+	raw := buildName(t, [][]ava{
+		{{oid: o, tag: cryptobyte_asn1.PrintableString, value: "Example Bank"}},
+		{{oid: cn, tag: cryptobyte_asn1.PrintableString, value: "api.example.com"}},
+	})
+
+	dn, err := ParseName(raw)
+	if err != nil {
+		t.Fatalf("ParseName: %v", err)
+	}
+	if len(dn.RDNs) != 2 {
+		t.Fatalf("len(RDNs) = %d, want 2", len(dn.RDNs))
+	}
+
+	brasil, err := dn.String(RenderOptions{Mode: ModeBrasil})
+	if err != nil {
+		t.Fatalf("String(ModeBrasil): %v", err)
+	}
+	if want := "CN=api.example.com,O=Example Bank"; brasil != want {
+		t.Errorf("ModeBrasil = %q, want %q", brasil, want)
+	}
+
+	rfc4514, err := dn.String(RenderOptions{Mode: ModeRFC4514})
+	if err != nil {
+		t.Fatalf("String(ModeRFC4514): %v", err)
+	}
+	if want := "O=Example Bank,CN=api.example.com"; rfc4514 != want {
+		t.Errorf("ModeRFC4514 = %q, want %q", rfc4514, want)
+	}
+
+	// Multi-valued RDN and an unrecognized OID fall back to the "#hex" form.
+	raw = buildName(t, [][]ava{
+		{
+			{oid: cn, tag: cryptobyte_asn1.PrintableString, value: "api.example.com"},
+			{oid: unknown, tag: cryptobyte_asn1.PrintableString, value: "AB"},
+		},
+	})
+	dn, err = ParseName(raw)
+	if err != nil {
+		t.Fatalf("ParseName: %v", err)
+	}
+	rfc4514, err = dn.String(RenderOptions{Mode: ModeRFC4514})
+	if err != nil {
+		t.Fatalf("String(ModeRFC4514): %v", err)
+	}
+	if want := "CN=api.example.com+1.2.3.4=#13024142"; rfc4514 != want {
+		t.Errorf("multi-valued RDN = %q, want %q", rfc4514, want)
+	}
+}
+
+func TestStringErrorsOnMalformedKnownAttribute(t *testing.T) {
+	cn := asn1.ObjectIdentifier{2, 5, 4, 3}
+	// CN is declared as a PrintableString but carries a byte outside the
+	// PrintableString alphabet, so it cannot be rendered by name.
+	raw := buildName(t, [][]ava{
+		{{oid: cn, tag: cryptobyte_asn1.PrintableString, value: "bad\x01value"}},
+	})
+	dn, err := ParseName(raw)
+	if err != nil {
+		t.Fatalf("ParseName: %v", err)
+	}
+	if _, err := dn.String(RenderOptions{Mode: ModeBrasil}); err == nil {
+		t.Fatal("String: expected error for malformed known-OID attribute, got nil")
+	} else if !strings.Contains(err.Error(), "invalid attribute value") {
+		t.Errorf("String error = %q, want it to mention an invalid attribute value", err)
+	}
+}